@@ -0,0 +1,149 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// discoverAPIFiles walks root recursively, picking up every file ending in
+// inputFileExt, including nested "core/" and "plugins/" layouts such as
+// those found under /usr/share/vpp/api. A file whose base name matches the
+// exclude glob (ignored entirely when exclude is empty) is skipped and
+// logged, letting operators leave out experimental plugins.
+func discoverAPIFiles(root, exclude string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, inputFileExt) {
+			return nil
+		}
+		if exclude != "" {
+			if matched, merr := filepath.Match(exclude, filepath.Base(path)); merr == nil && matched {
+				logf("skipping excluded file %q", path)
+				return nil
+			}
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("binapi-generator: walking %q: %v", root, err)
+	}
+	return files, nil
+}
+
+// generateDirectory regenerates an entire VPP API tree rooted at inputDir in
+// one call: it discovers every *.api.json file under inputDir (honoring
+// exclude), builds one context per file, drops duplicate modules (same
+// module name and CRC reached via more than one path), and generates the
+// surviving packages in parallel.
+func generateDirectory(inputDir, outputDir, exclude string) error {
+	files, err := discoverAPIFiles(inputDir, exclude)
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}          // "<module>@<crc>" already queued for generation
+	outputPaths := map[string]string{} // ctx.outputFile -> module name already claiming it
+	var ctxs []*context
+	for _, file := range files {
+		ctx, err := getContext(file, outputDir)
+		if err != nil {
+			logf("skipping %q: %v", file, err)
+			continue
+		}
+
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			logf("skipping %q: %v", file, err)
+			continue
+		}
+		ctx.inputData = data
+
+		pkg, err := parsePackage(ctx, data)
+		if err != nil {
+			logf("skipping %q: %v", file, err)
+			continue
+		}
+		ctx.packageData = pkg
+
+		key := ctx.moduleName + "@" + pkg.CRC
+		if seen[key] {
+			logf("skipping duplicate module %q (CRC %s) from %q", ctx.moduleName, pkg.CRC, file)
+			continue
+		}
+		seen[key] = true
+
+		// Two distinct modules (different name-and-CRC keys above) can still
+		// land on the same output path, e.g. same name reached via both
+		// core/ and plugins/ with different CRCs. getContext derives
+		// outputFile from packageName alone, so without this check they'd
+		// race concurrent os.Create/writes in the loop below instead of
+		// failing loudly.
+		if owner, ok := outputPaths[ctx.outputFile]; ok {
+			logf("skipping module %q (CRC %s) from %q: output %q is already claimed by module %q", ctx.moduleName, pkg.CRC, file, ctx.outputFile, owner)
+			continue
+		}
+		outputPaths[ctx.outputFile] = ctx.moduleName
+
+		ctxs = append(ctxs, ctx)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(ctxs))
+	for _, ctx := range ctxs {
+		wg.Add(1)
+		go func(ctx *context) {
+			defer wg.Done()
+			if err := generateToFile(ctx); err != nil {
+				errs <- fmt.Errorf("generating %q: %v", ctx.inputFile, err)
+			}
+		}(ctx)
+	}
+	wg.Wait()
+	close(errs)
+
+	var failures []string
+	for err := range errs {
+		failures = append(failures, err.Error())
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("binapi-generator: %d package(s) failed to generate:\n%s", len(failures), strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// generateToFile creates ctx.outputFile (and its parent directory) and
+// writes the generated package into it.
+func generateToFile(ctx *context) error {
+	if err := os.MkdirAll(filepath.Dir(ctx.outputFile), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(ctx.outputFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return generatePackage(ctx, f)
+}
@@ -55,6 +55,11 @@ type context struct {
 	includeBinapiNames bool // include binary API names as struct tag
 	includeServices    bool // include service interface with client implementation
 
+	generateCodec       bool // generate Size/Marshal/Unmarshal methods instead of struc tags (set via the -binapi-codec flag)
+	includeHTTPHandlers bool // generate an HTTPHandler(rpc Service) http.Handler exposing RPC methods as REST endpoints (set via the -http-handlers flag)
+
+	plugins []string // plugin names to run, in requested order (set via the -plugins flag; empty means defaultPlugins)
+
 	moduleName  string // name of the source VPP module
 	packageName string // name of the Go package being generated
 
@@ -93,12 +98,31 @@ func getContext(inputFile, outputDir string) (*context, error) {
 	return ctx, nil
 }
 
-// generatePackage generates code for the parsed package data and writes it into w
+// generatePackage generates code for the parsed package data and writes it
+// into w. The enum/alias/type/union/message/service/REST emitters run as
+// Plugins (see plugin.go); w and ctx.outputFile together back the
+// GeneratedFile those plugins write through.
 func generatePackage(ctx *context, w io.Writer) error {
 	logf("generating package %q", ctx.packageName)
 
+	plugins, err := ctx.resolvePlugins()
+	if err != nil {
+		return err
+	}
+
+	// Run the plugins first, into a buffered GeneratedFile, so that any
+	// gen.Import call lands in the fixed import block below instead of
+	// wherever in the declaration stream the plugin happened to call it.
+	gen := newGeneratedFile(ctx)
+	for _, p := range plugins {
+		if err := p.Generate(ctx, gen); err != nil {
+			return fmt.Errorf("binapi-generator: plugin %q: %v", p.Name(), err)
+		}
+	}
+
 	// generate file header
 	generateHeader(ctx, w)
+	gen.writeExtraImports(w)
 	generateImports(ctx, w)
 
 	// generate module desc
@@ -117,79 +141,7 @@ func generatePackage(ctx *context, w io.Writer) error {
 	fmt.Fprintln(w, ")")
 	fmt.Fprintln(w)
 
-	// generate enums
-	if len(ctx.packageData.Enums) > 0 {
-		fmt.Fprintf(w, "/* Enums */\n\n")
-
-		for _, enum := range ctx.packageData.Enums {
-			generateEnum(ctx, w, &enum)
-		}
-	}
-
-	// generate aliases
-	if len(ctx.packageData.Aliases) > 0 {
-		fmt.Fprintf(w, "/* Aliases */\n\n")
-
-		for _, alias := range ctx.packageData.Aliases {
-			generateAlias(ctx, w, &alias)
-		}
-	}
-
-	// generate types
-	if len(ctx.packageData.Types) > 0 {
-		fmt.Fprintf(w, "/* Types */\n\n")
-
-		for _, typ := range ctx.packageData.Types {
-			generateType(ctx, w, &typ)
-		}
-	}
-
-	// generate unions
-	if len(ctx.packageData.Unions) > 0 {
-		fmt.Fprintf(w, "/* Unions */\n\n")
-
-		for _, union := range ctx.packageData.Unions {
-			generateUnion(ctx, w, &union)
-		}
-	}
-
-	// generate messages
-	if len(ctx.packageData.Messages) > 0 {
-		fmt.Fprintf(w, "/* Messages */\n\n")
-
-		for _, msg := range ctx.packageData.Messages {
-			generateMessage(ctx, w, &msg)
-		}
-
-		// generate message registrations
-		fmt.Fprintln(w, "func init() {")
-		for _, msg := range ctx.packageData.Messages {
-			name := camelCaseName(msg.Name)
-			fmt.Fprintf(w, "\tapi.RegisterMessage((*%s)(nil), \"%s\")\n", name, ctx.moduleName+"."+name)
-		}
-		fmt.Fprintln(w, "}")
-		fmt.Fprintln(w)
-
-		// generate list of messages
-		fmt.Fprintf(w, "// Messages returns list of all messages in this module.\n")
-		fmt.Fprintln(w, "func AllMessages() []api.Message {")
-		fmt.Fprintln(w, "\treturn []api.Message{")
-		for _, msg := range ctx.packageData.Messages {
-			name := camelCaseName(msg.Name)
-			fmt.Fprintf(w, "\t(*%s)(nil),\n", name)
-		}
-		fmt.Fprintln(w, "}")
-		fmt.Fprintln(w, "}")
-	}
-
-	if ctx.includeServices {
-		// generate services
-		if len(ctx.packageData.Services) > 0 {
-			generateServices(ctx, w, ctx.packageData.Services)
-		}
-	}
-
-	return nil
+	return gen.flush(w)
 }
 
 // generateHeader writes generated package header into w
@@ -231,18 +183,50 @@ func generateHeader(ctx *context, w io.Writer) {
 // generateImports writes generated package imports into w
 func generateImports(ctx *context, w io.Writer) {
 	fmt.Fprintf(w, "import api \"%s\"\n", govppApiImportPath)
-	fmt.Fprintf(w, "import bytes \"%s\"\n", "bytes")
+	if !ctx.generateCodec {
+		fmt.Fprintf(w, "import bytes \"%s\"\n", "bytes")
+	}
 	fmt.Fprintf(w, "import context \"%s\"\n", "context")
+	if ctx.generateCodec {
+		fmt.Fprintf(w, "import binary \"%s\"\n", "encoding/binary")
+	}
+	fmt.Fprintf(w, "import io \"%s\"\n", "io")
 	fmt.Fprintf(w, "import strconv \"%s\"\n", "strconv")
-	fmt.Fprintf(w, "import struc \"%s\"\n", "github.com/lunixbochs/struc")
+	if !ctx.generateCodec {
+		fmt.Fprintf(w, "import struc \"%s\"\n", "github.com/lunixbochs/struc")
+	}
+	if ctx.includeServices && ctx.includeHTTPHandlers {
+		fmt.Fprintf(w, "import json \"%s\"\n", "encoding/json")
+		fmt.Fprintf(w, "import http \"%s\"\n", "net/http")
+	}
+	if len(ctx.packageData.Messages) > 0 {
+		fmt.Fprintf(w, "import fmt \"%s\"\n", "fmt")
+		fmt.Fprintf(w, "import strings \"%s\"\n", "strings")
+	}
 	fmt.Fprintln(w)
 
 	fmt.Fprintf(w, "// Reference imports to suppress errors if they are not otherwise used.\n")
 	fmt.Fprintf(w, "var _ = api.RegisterMessage\n")
-	fmt.Fprintf(w, "var _ = bytes.NewBuffer\n")
+	if !ctx.generateCodec {
+		fmt.Fprintf(w, "var _ = bytes.NewBuffer\n")
+	}
 	fmt.Fprintf(w, "var _ = context.Background\n")
+	if ctx.generateCodec {
+		fmt.Fprintf(w, "var _ = binary.BigEndian\n")
+	}
+	fmt.Fprintf(w, "var _ = io.EOF\n")
 	fmt.Fprintf(w, "var _ = strconv.Itoa\n")
-	fmt.Fprintf(w, "var _ = struc.Pack\n")
+	if !ctx.generateCodec {
+		fmt.Fprintf(w, "var _ = struc.Pack\n")
+	}
+	if ctx.includeServices && ctx.includeHTTPHandlers {
+		fmt.Fprintf(w, "var _ = json.Marshal\n")
+		fmt.Fprintf(w, "var _ = http.Error\n")
+	}
+	if len(ctx.packageData.Messages) > 0 {
+		fmt.Fprintf(w, "var _ = fmt.Sprintf\n")
+		fmt.Fprintf(w, "var _ = strings.Join\n")
+	}
 	fmt.Fprintln(w)
 
 	fmt.Fprintln(w, "// This is a compile-time assertion to ensure that this generated file")
@@ -332,6 +316,13 @@ func generateServices(ctx *context, w io.Writer, services []Service) {
 	fmt.Fprintln(w, "}")
 	fmt.Fprintln(w)
 
+	// generate reply stream types for the streaming methods
+	for _, svc := range services {
+		if svc.Stream {
+			generateStreamType(ctx, w, &svc)
+		}
+	}
+
 	// generate client implementation
 	fmt.Fprintf(w, "type %s struct {\n", implName)
 	fmt.Fprintf(w, "\tch api.Channel\n")
@@ -348,19 +339,15 @@ func generateServices(ctx *context, w io.Writer, services []Service) {
 		generateServiceMethod(ctx, w, &svc)
 		fmt.Fprintln(w, " {")
 		if svc.Stream {
-			// TODO: stream responses
-			//fmt.Fprintf(w, "\tstream := make(chan *%s)\n", camelCaseName(svc.ReplyType))
-			replyTyp := camelCaseName(svc.ReplyType)
-			fmt.Fprintf(w, "\tvar dump []*%s\n", replyTyp)
+			method := streamMethodName(&svc)
+			streamImpl := streamImplName(method)
+			// SendMultiRequest already appends the terminating control ping
+			// VPP needs to close a dump, and MultiRequestCtx.ReceiveReply
+			// (see generateStreamType's Recv) reports that ping's reply as
+			// stop=true, so callers drain the stream via Recv/io.EOF instead
+			// of waiting for the whole dump to buffer in memory.
 			fmt.Fprintf(w, "\treq := c.ch.SendMultiRequest(in)\n")
-			fmt.Fprintf(w, "\tfor {\n")
-			fmt.Fprintf(w, "\tm := new(%s)\n", replyTyp)
-			fmt.Fprintf(w, "\tstop, err := req.ReceiveReply(m)\n")
-			fmt.Fprintf(w, "\tif stop { break }\n")
-			fmt.Fprintf(w, "\tif err != nil { return nil, err }\n")
-			fmt.Fprintf(w, "\tdump = append(dump, m)\n")
-			fmt.Fprintln(w, "}")
-			fmt.Fprintf(w, "\treturn dump, nil\n")
+			fmt.Fprintf(w, "\treturn &%s{req: req}, nil\n", streamImpl)
 		} else if replyTyp := camelCaseName(svc.ReplyType); replyTyp != "" {
 			fmt.Fprintf(w, "\tout := new(%s)\n", replyTyp)
 			fmt.Fprintf(w, "\terr:= c.ch.SendRequest(in).ReceiveReply(out)\n")
@@ -377,29 +364,166 @@ func generateServices(ctx *context, w io.Writer, services []Service) {
 	fmt.Fprintln(w)
 }
 
-// generateServiceMethod writes generated code for the service into w
-func generateServiceMethod(ctx *context, w io.Writer, svc *Service) {
-	reqTyp := camelCaseName(svc.RequestType)
-
-	// method name is same as parameter type name by default
-	method := reqTyp
+// streamMethodName returns the generated method name for a service, using
+// the Dump prefix convention for streaming (multi-request) services.
+func streamMethodName(svc *Service) string {
+	method := camelCaseName(svc.RequestType)
 	if svc.Stream {
 		// use Dump as prefix instead of suffix for stream services
 		if m := strings.TrimSuffix(method, "Dump"); method != m {
 			method = "Dump" + m
 		}
 	}
+	return method
+}
+
+// streamImplName returns the name of the unexported type implementing the
+// <Method>RPCStream interface for a streaming method.
+func streamImplName(method string) string {
+	return strings.ToLower(method[:1]) + method[1:] + "RPCStream"
+}
+
+// httpRoute returns the REST route a service method is mounted at.
+func httpRoute(ctx *context, svc *Service) string {
+	return fmt.Sprintf("/vpp/%s/%s", ctx.moduleName, strings.ToLower(streamMethodName(svc)))
+}
+
+// generateHTTPHandlers writes an HTTPHandler that exposes every Service
+// method as a REST endpoint into w: non-stream methods decode a JSON request
+// body and encode a JSON reply, stream methods write a JSON object per
+// message until the underlying RPC stream returns io.EOF, flushing after
+// each one.
+func generateHTTPHandlers(ctx *context, w io.Writer, services []Service) {
+	fmt.Fprintf(w, "// HTTPHandler returns an http.Handler that exposes every method of Service\n")
+	fmt.Fprintf(w, "// as a POST endpoint under /vpp/%s/, decoding the request body and\n", ctx.moduleName)
+	fmt.Fprintf(w, "// encoding the reply (or replies, for streaming methods) as JSON.\n")
+	fmt.Fprintln(w, "func HTTPHandler(rpc Service) http.Handler {")
+	fmt.Fprintln(w, "\tmux := http.NewServeMux()")
+	for _, svc := range services {
+		method := streamMethodName(&svc)
+		reqTyp := camelCaseName(svc.RequestType)
+
+		fmt.Fprintf(w, "\tmux.HandleFunc(%q, func(w http.ResponseWriter, r *http.Request) {\n", httpRoute(ctx, &svc))
+		fmt.Fprintln(w, "\t\tif r.Method != http.MethodPost {")
+		fmt.Fprintln(w, "\t\t\thttp.Error(w, \"method not allowed\", http.StatusMethodNotAllowed)")
+		fmt.Fprintln(w, "\t\t\treturn")
+		fmt.Fprintln(w, "\t\t}")
+		fmt.Fprintf(w, "\t\tin := new(%s)\n", reqTyp)
+		fmt.Fprintln(w, "\t\tif err := json.NewDecoder(r.Body).Decode(in); err != nil {")
+		fmt.Fprintln(w, "\t\t\thttp.Error(w, err.Error(), http.StatusBadRequest)")
+		fmt.Fprintln(w, "\t\t\treturn")
+		fmt.Fprintln(w, "\t\t}")
 
-	params := fmt.Sprintf("in *%s", reqTyp)
-	returns := "error"
-	if replyType := camelCaseName(svc.ReplyType); replyType != "" {
-		replyTyp := fmt.Sprintf("*%s", replyType)
 		if svc.Stream {
-			// TODO: stream responses
-			//replyTyp = fmt.Sprintf("<-chan %s", replyTyp)
-			replyTyp = fmt.Sprintf("[]%s", replyTyp)
+			fmt.Fprintf(w, "\t\tstream, err := rpc.%s(r.Context(), in)\n", method)
+			fmt.Fprintln(w, "\t\tif err != nil {")
+			fmt.Fprintln(w, "\t\t\thttp.Error(w, err.Error(), http.StatusInternalServerError)")
+			fmt.Fprintln(w, "\t\t\treturn")
+			fmt.Fprintln(w, "\t\t}")
+			fmt.Fprintln(w, "\t\tdefer stream.Close()")
+			fmt.Fprintln(w, "\t\tflusher, _ := w.(http.Flusher)")
+			fmt.Fprintln(w, "\t\tenc := json.NewEncoder(w)")
+			fmt.Fprintln(w, "\t\tfor {")
+			fmt.Fprintln(w, "\t\t\treply, err := stream.Recv()")
+			fmt.Fprintln(w, "\t\t\tif err == io.EOF {")
+			fmt.Fprintln(w, "\t\t\t\tbreak")
+			fmt.Fprintln(w, "\t\t\t}")
+			fmt.Fprintln(w, "\t\t\tif err != nil {")
+			fmt.Fprintln(w, "\t\t\t\thttp.Error(w, err.Error(), http.StatusInternalServerError)")
+			fmt.Fprintln(w, "\t\t\t\treturn")
+			fmt.Fprintln(w, "\t\t\t}")
+			fmt.Fprintln(w, "\t\t\tif err := enc.Encode(reply); err != nil {")
+			fmt.Fprintln(w, "\t\t\t\treturn")
+			fmt.Fprintln(w, "\t\t\t}")
+			fmt.Fprintln(w, "\t\t\tif flusher != nil {")
+			fmt.Fprintln(w, "\t\t\t\tflusher.Flush()")
+			fmt.Fprintln(w, "\t\t\t}")
+			fmt.Fprintln(w, "\t\t}")
+		} else if replyType := camelCaseName(svc.ReplyType); replyType != "" {
+			fmt.Fprintf(w, "\t\tout, err := rpc.%s(r.Context(), in)\n", method)
+			fmt.Fprintln(w, "\t\tif err != nil {")
+			fmt.Fprintln(w, "\t\t\thttp.Error(w, err.Error(), http.StatusInternalServerError)")
+			fmt.Fprintln(w, "\t\t\treturn")
+			fmt.Fprintln(w, "\t\t}")
+			fmt.Fprintln(w, "\t\tjson.NewEncoder(w).Encode(out)")
+		} else {
+			fmt.Fprintf(w, "\t\tif err := rpc.%s(r.Context(), in); err != nil {\n", method)
+			fmt.Fprintln(w, "\t\t\thttp.Error(w, err.Error(), http.StatusInternalServerError)")
+			fmt.Fprintln(w, "\t\t\treturn")
+			fmt.Fprintln(w, "\t\t}")
 		}
-		returns = fmt.Sprintf("(%s, error)", replyTyp)
+		fmt.Fprintln(w, "\t})")
+	}
+	fmt.Fprintln(w, "\treturn mux")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "// Routes returns the REST routes mounted by HTTPHandler, for discovery.")
+	fmt.Fprintln(w, "func Routes() []string {")
+	fmt.Fprintln(w, "\treturn []string{")
+	for _, svc := range services {
+		fmt.Fprintf(w, "\t\t%q,\n", httpRoute(ctx, &svc))
+	}
+	fmt.Fprintln(w, "\t}")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+}
+
+// generateStreamType writes the reply-stream interface and its channel-backed
+// implementation for a streaming service method into w.
+func generateStreamType(ctx *context, w io.Writer, svc *Service) {
+	method := streamMethodName(svc)
+	replyTyp := camelCaseName(svc.ReplyType)
+	streamIface := method + "RPCStream"
+	streamImpl := streamImplName(method)
+
+	fmt.Fprintf(w, "// %s is a stream of %s replies, one per Recv call.\n", streamIface, replyTyp)
+	fmt.Fprintf(w, "type %s interface {\n", streamIface)
+	fmt.Fprintf(w, "\tRecv() (*%s, error)\n", replyTyp)
+	fmt.Fprintf(w, "\tClose() error\n")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "type %s struct {\n", streamImpl)
+	fmt.Fprintf(w, "\treq  api.MultiRequestCtx\n")
+	fmt.Fprintf(w, "\tdone bool // set once ReceiveReply has reported the terminating control ping\n")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "func (c *%s) Recv() (*%s, error) {\n", streamImpl, replyTyp)
+	fmt.Fprintf(w, "\tm := new(%s)\n", replyTyp)
+	fmt.Fprintf(w, "\tstop, err := c.req.ReceiveReply(m)\n")
+	fmt.Fprintf(w, "\tif err != nil {\n\t\tc.done = true\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(w, "\tif stop {\n\t\tc.done = true\n\t\treturn nil, io.EOF\n\t}\n")
+	fmt.Fprintf(w, "\treturn m, nil\n")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "// Close drains any replies the caller stopped Recv'ing before the\n")
+	fmt.Fprintf(w, "// terminating control ping, so an early Close still leaves the channel\n")
+	fmt.Fprintf(w, "// clean for the next request instead of leaking the rest of this dump.\n")
+	fmt.Fprintf(w, "func (c *%s) Close() error {\n", streamImpl)
+	fmt.Fprintf(w, "\tfor !c.done {\n")
+	fmt.Fprintf(w, "\t\tstop, err := c.req.ReceiveReply(new(%s))\n", replyTyp)
+	fmt.Fprintf(w, "\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n")
+	fmt.Fprintf(w, "\t\tc.done = stop\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\treturn nil\n")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+}
+
+// generateServiceMethod writes generated code for the service into w
+func generateServiceMethod(ctx *context, w io.Writer, svc *Service) {
+	method := streamMethodName(svc)
+	reqTyp := camelCaseName(svc.RequestType)
+
+	params := fmt.Sprintf("in *%s", reqTyp)
+	returns := "error"
+	if svc.Stream {
+		returns = fmt.Sprintf("(%sRPCStream, error)", method)
+	} else if replyType := camelCaseName(svc.ReplyType); replyType != "" {
+		returns = fmt.Sprintf("(*%s, error)", replyType)
 	}
 
 	fmt.Fprintf(w, "\t%s(ctx context.Context, %s) %s", method, params, returns)
@@ -470,6 +594,75 @@ func generateAlias(ctx *context, w io.Writer, alias *Alias) {
 	fmt.Fprintf(w, "%s\n", dataType)
 
 	fmt.Fprintln(w)
+
+	if ctx.generateCodec {
+		generateAliasCodec(ctx, w, name, alias)
+	}
+}
+
+// generateAliasCodec writes Size/Marshal/Unmarshal methods for an alias
+// (a fixed-size array or a bare scalar) into w. Message/type fields
+// generated with an alias's Go type rely on generateFieldSize/Marshal/
+// Unmarshal's non-primitive fallback, which calls exactly these methods -
+// ip4/ip6/mac-address and similar aliases need them to compile under
+// -binapi-codec just as much as a generated struct type does.
+func generateAliasCodec(ctx *context, w io.Writer, name string, alias *Alias) {
+	dataType := convertToGoType(ctx, alias.Type)
+	n, isPrimitive := binapiCodecPrimitives[dataType]
+
+	fmt.Fprintf(w, "func (x *%s) Size() (size int) {\n", name)
+	switch {
+	case alias.Length > 0 && isPrimitive:
+		fmt.Fprintf(w, "\tsize += %d * %d\n", alias.Length, n)
+	case alias.Length > 0:
+		fmt.Fprintln(w, "\tfor i := range x {\n\t\tsize += x[i].Size()\n\t}")
+	case isPrimitive:
+		fmt.Fprintf(w, "\tsize += %d\n", n)
+	default:
+		fmt.Fprintf(w, "\tsize += (*%s)(x).Size()\n", dataType)
+	}
+	fmt.Fprintln(w, "\treturn size")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "func (x *%s) Marshal(b []byte) ([]byte, error) {\n", name)
+	fmt.Fprintln(w, "\tif b == nil {")
+	fmt.Fprintln(w, "\t\tb = make([]byte, 0, x.Size())")
+	fmt.Fprintln(w, "\t}")
+	switch {
+	case alias.Length > 0:
+		fmt.Fprintf(w, "\tfor i := 0; i < %d; i++ {\n", alias.Length)
+		codecScalarWrite(w, "x[i]", dataType)
+		fmt.Fprintln(w, "\t}")
+	case isPrimitive:
+		codecScalarWrite(w, "*x", dataType)
+	default:
+		// *x shares Name's own Marshal (the method being generated here),
+		// so convert to the underlying alias type to call its Marshal
+		// instead of recursing into this one.
+		fmt.Fprintf(w, "\tif nb, err := (*%s)(x).Marshal(nil); err == nil {\n\t\tb = append(b, nb...)\n\t}\n", dataType)
+	}
+	fmt.Fprintln(w, "\treturn b, nil")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "func (x *%s) Unmarshal(b []byte) error {\n", name)
+	fmt.Fprintln(w, "\tpos := 0")
+	switch {
+	case alias.Length > 0:
+		fmt.Fprintf(w, "\tfor i := 0; i < %d; i++ {\n", alias.Length)
+		codecScalarRead(w, "x[i]", dataType)
+		fmt.Fprintln(w, "\t}")
+	case isPrimitive:
+		fmt.Fprintf(w, "\tvar v %s\n", dataType)
+		codecScalarRead(w, "v", dataType)
+		fmt.Fprintf(w, "\t*x = %s(v)\n", name)
+	default:
+		fmt.Fprintf(w, "\tif err := (*%s)(x).Unmarshal(b[pos:]); err == nil {\n\t\tpos += x.Size()\n\t}\n", dataType)
+	}
+	fmt.Fprintln(w, "\treturn nil")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
 }
 
 // generateUnion writes generated code for the union into w
@@ -505,15 +698,27 @@ func generateUnion(ctx *context, w io.Writer, union *Union) {
 	for _, field := range union.Fields {
 		fieldName := camelCaseName(field.Name)
 		fieldType := convertToGoType(ctx, field.Type)
-		generateUnionGetterSetter(w, name, fieldName, fieldType)
+		generateUnionGetterSetter(ctx, w, name, fieldName, fieldType)
 	}
 
 	// generate union methods
 	//generateUnionMethods(w, name)
 
+	if ctx.generateCodec {
+		generateUnionCodec(w, name, maxSize)
+	}
+
 	fmt.Fprintln(w)
 }
 
+// generateUnionCodec writes the Size/Marshal/Unmarshal methods for a union
+// into w. A union always marshals to exactly size bytes: the raw union data.
+func generateUnionCodec(w io.Writer, structName string, size int) {
+	fmt.Fprintf(w, "func (u *%s) Size() int {\n\treturn %d\n}\n\n", structName, size)
+	fmt.Fprintf(w, "func (u *%s) Marshal(b []byte) ([]byte, error) {\n\treturn append(b, u.%s[:]...), nil\n}\n\n", structName, unionDataField)
+	fmt.Fprintf(w, "func (u *%s) Unmarshal(b []byte) error {\n\tcopy(u.%s[:], b[:%d])\n\treturn nil\n}\n\n", structName, unionDataField, size)
+}
+
 // generateUnionMethods generates methods that implement struc.Custom
 // interface to allow having XXX_uniondata field unexported
 // TODO: do more testing when unions are actually used in some messages
@@ -540,8 +745,9 @@ func (u *%[1]s) String() string {
 `, structName)
 }*/
 
-func generateUnionGetterSetter(w io.Writer, structName string, getterField, getterStruct string) {
-	fmt.Fprintf(w, `
+func generateUnionGetterSetter(ctx *context, w io.Writer, structName string, getterField, getterStruct string) {
+	if !ctx.generateCodec {
+		fmt.Fprintf(w, `
 func %[1]s%[2]s(a %[3]s) (u %[1]s) {
 	u.Set%[2]s(a)
 	return
@@ -559,6 +765,225 @@ func (u *%[1]s) Get%[2]s() (a %[3]s) {
 	return
 }
 `, structName, getterField, getterStruct, unionDataField)
+		return
+	}
+
+	// codec mode: memcpy the field bytes directly into the union data array
+	// instead of round-tripping through struc.Pack/Unpack.
+	fmt.Fprintf(w, "\nfunc %[1]s%[2]s(a %[3]s) (u %[1]s) {\n\tu.Set%[2]s(a)\n\treturn\n}\n", structName, getterField, getterStruct)
+
+	fmt.Fprintf(w, "func (u *%s) Set%s(a %s) {\n\tvar b []byte\n", structName, getterField, getterStruct)
+	codecScalarWrite(w, "a", getterStruct)
+	fmt.Fprintf(w, "\tcopy(u.%s[:], b)\n}\n", unionDataField)
+
+	fmt.Fprintf(w, "func (u *%s) Get%s() (a %s) {\n\tpos := 0\n\tb := u.%s[:]\n", structName, getterField, getterStruct, unionDataField)
+	codecScalarRead(w, "a", getterStruct)
+	fmt.Fprintf(w, "\treturn\n}\n\n")
+}
+
+// codecScalarWrite appends the binary.BigEndian encoding of the primitive
+// scalar expr to the in-scope []byte variable b. Non-primitive (nested
+// generated) types are encoded via their own Marshal method instead.
+func codecScalarWrite(w io.Writer, expr, dataType string) {
+	switch dataType {
+	case "bool":
+		fmt.Fprintf(w, "\tif %s {\n\t\tb = append(b, 1)\n\t} else {\n\t\tb = append(b, 0)\n\t}\n", expr)
+	case "uint8", "byte", "int8":
+		fmt.Fprintf(w, "\tb = append(b, byte(%s))\n", expr)
+	case "uint16", "int16":
+		fmt.Fprintf(w, "\tb = append(b, 0, 0)\n\tbinary.BigEndian.PutUint16(b[len(b)-2:], uint16(%s))\n", expr)
+	case "uint32", "int32":
+		fmt.Fprintf(w, "\tb = append(b, 0, 0, 0, 0)\n\tbinary.BigEndian.PutUint32(b[len(b)-4:], uint32(%s))\n", expr)
+	case "uint64", "int64":
+		fmt.Fprintf(w, "\tb = append(b, 0, 0, 0, 0, 0, 0, 0, 0)\n\tbinary.BigEndian.PutUint64(b[len(b)-8:], uint64(%s))\n", expr)
+	default:
+		fmt.Fprintf(w, "\tif nb, err := (&%s).Marshal(nil); err == nil {\n\t\tb = append(b, nb...)\n\t}\n", expr)
+	}
+}
+
+// codecScalarRead decodes a primitive scalar from the in-scope []byte
+// variable b starting at the in-scope int variable pos into target,
+// advancing pos. Non-primitive (nested generated) types are decoded via
+// their own Unmarshal method instead.
+func codecScalarRead(w io.Writer, target, dataType string) {
+	switch dataType {
+	case "bool":
+		fmt.Fprintf(w, "\t%s = b[pos] != 0\n\tpos++\n", target)
+	case "uint8", "byte", "int8":
+		fmt.Fprintf(w, "\t%s = %s(b[pos])\n\tpos++\n", target, dataType)
+	case "uint16", "int16":
+		fmt.Fprintf(w, "\t%s = %s(binary.BigEndian.Uint16(b[pos:]))\n\tpos += 2\n", target, dataType)
+	case "uint32", "int32":
+		fmt.Fprintf(w, "\t%s = %s(binary.BigEndian.Uint32(b[pos:]))\n\tpos += 4\n", target, dataType)
+	case "uint64", "int64":
+		fmt.Fprintf(w, "\t%s = %s(binary.BigEndian.Uint64(b[pos:]))\n\tpos += 8\n", target, dataType)
+	default:
+		fmt.Fprintf(w, "\tif err := (&%s).Unmarshal(b[pos:]); err == nil {\n\t\tpos += %s.Size()\n\t}\n", target, target)
+	}
+}
+
+// generateFieldsCodec writes Size/Marshal/Unmarshal methods for a generated
+// struct backed by fields (the subset of fields that were actually emitted
+// as struct members) into w.
+func generateFieldsCodec(ctx *context, w io.Writer, structName string, fields []Field) {
+	countOf := map[string]string{} // count field's raw name -> its array field's Go name
+	for _, f := range fields {
+		if f.SizeFrom != "" {
+			countOf[f.SizeFrom] = camelCaseName(strings.TrimPrefix(f.Name, "_"))
+		}
+	}
+
+	fmt.Fprintf(w, "func (m *%s) Size() (size int) {\n", structName)
+	for _, field := range fields {
+		generateFieldSize(ctx, w, &field, countOf)
+	}
+	fmt.Fprintln(w, "\treturn size")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "func (m *%s) Marshal(b []byte) ([]byte, error) {\n", structName)
+	fmt.Fprintln(w, "\tif b == nil {")
+	fmt.Fprintln(w, "\t\tb = make([]byte, 0, m.Size())")
+	fmt.Fprintln(w, "\t}")
+	for _, field := range fields {
+		generateFieldMarshal(ctx, w, &field, countOf)
+	}
+	fmt.Fprintln(w, "\treturn b, nil")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "func (m *%s) Unmarshal(b []byte) error {\n", structName)
+	fmt.Fprintln(w, "\tpos := 0")
+	for _, field := range fields {
+		generateFieldUnmarshal(ctx, w, &field, countOf)
+	}
+	fmt.Fprintln(w, "\treturn nil")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+}
+
+// generateFieldSize writes the Size() contribution of field into w.
+func generateFieldSize(ctx *context, w io.Writer, field *Field, countOf map[string]string) {
+	fieldName := camelCaseName(strings.TrimPrefix(field.Name, "_"))
+	dataType := convertToGoType(ctx, field.Type)
+
+	if field.Type == "string" {
+		fmt.Fprintf(w, "\tsize += 4 + len(m.%s)\n", fieldName)
+		return
+	}
+	if field.Length > 0 {
+		if n, ok := binapiCodecPrimitives[dataType]; ok {
+			fmt.Fprintf(w, "\tsize += %d * %d // %s\n", field.Length, n, fieldName)
+		} else {
+			fmt.Fprintf(w, "\tfor i := range m.%s {\n\t\tsize += m.%s[i].Size()\n\t}\n", fieldName, fieldName)
+		}
+		return
+	}
+	if field.SizeFrom != "" {
+		// variable-length array: its wire size tracks however many elements
+		// are actually in the slice, not a fixed width.
+		if n, ok := binapiCodecPrimitives[dataType]; ok {
+			fmt.Fprintf(w, "\tsize += len(m.%s) * %d\n", fieldName, n)
+		} else {
+			fmt.Fprintf(w, "\tfor i := range m.%s {\n\t\tsize += m.%s[i].Size()\n\t}\n", fieldName, fieldName)
+		}
+		return
+	}
+	if n, ok := binapiCodecPrimitives[dataType]; ok {
+		fmt.Fprintf(w, "\tsize += %d // %s\n", n, fieldName)
+	} else {
+		fmt.Fprintf(w, "\tsize += m.%s.Size()\n", fieldName)
+	}
+}
+
+// generateFieldMarshal writes the Marshal() encoding of field into w.
+func generateFieldMarshal(ctx *context, w io.Writer, field *Field, countOf map[string]string) {
+	fieldName := camelCaseName(strings.TrimPrefix(field.Name, "_"))
+	dataType := convertToGoType(ctx, field.Type)
+	expr := "m." + fieldName
+
+	if field.Type == "string" {
+		fmt.Fprintf(w, "\tb = append(b, 0, 0, 0, 0)\n")
+		fmt.Fprintf(w, "\tbinary.BigEndian.PutUint32(b[len(b)-4:], uint32(len(%s)))\n", expr)
+		fmt.Fprintf(w, "\tb = append(b, %s...)\n", expr)
+		return
+	}
+	if field.Length > 0 {
+		fmt.Fprintf(w, "\tfor i := 0; i < %d; i++ {\n", field.Length)
+		codecScalarWrite(w, expr+"[i]", dataType)
+		fmt.Fprintln(w, "\t}")
+		return
+	}
+	if arrayField, isCount := countOf[field.Name]; isCount {
+		// this field only exists on the wire as the element count of
+		// arrayField, so always derive it from len() rather than trusting
+		// whatever the caller last assigned it.
+		codecScalarWrite(w, fmt.Sprintf("len(m.%s)", arrayField), dataType)
+		return
+	}
+	if field.SizeFrom != "" {
+		fmt.Fprintf(w, "\tfor i := range %s {\n", expr)
+		codecScalarWrite(w, expr+"[i]", dataType)
+		fmt.Fprintln(w, "\t}")
+		return
+	}
+	codecScalarWrite(w, expr, dataType)
+}
+
+// generateFieldUnmarshal writes the Unmarshal() decoding of field into w.
+func generateFieldUnmarshal(ctx *context, w io.Writer, field *Field, countOf map[string]string) {
+	fieldName := camelCaseName(strings.TrimPrefix(field.Name, "_"))
+	dataType := convertToGoType(ctx, field.Type)
+	target := "m." + fieldName
+
+	if field.Type == "string" {
+		fmt.Fprintf(w, "\tn := binary.BigEndian.Uint32(b[pos:])\n\tpos += 4\n")
+		fmt.Fprintf(w, "\t%s = string(b[pos : pos+int(n)])\n\tpos += int(n)\n", target)
+		return
+	}
+	if field.Length > 0 {
+		fmt.Fprintf(w, "\t%s = make([]%s, %d)\n", target, dataType, field.Length)
+		fmt.Fprintf(w, "\tfor i := 0; i < %d; i++ {\n", field.Length)
+		codecScalarRead(w, target+"[i]", dataType)
+		fmt.Fprintln(w, "\t}")
+		return
+	}
+	if arrayField, isCount := countOf[field.Name]; isCount {
+		// read the element count into a local var: the array field it
+		// describes (processed next) allocates from that, since Marshal
+		// always derives the count from len() rather than this field.
+		localVar := "count" + arrayField
+		fmt.Fprintf(w, "\tvar %s %s\n", localVar, dataType)
+		codecScalarRead(w, localVar, dataType)
+		fmt.Fprintf(w, "\t%s = %s\n", target, localVar)
+		return
+	}
+	if field.SizeFrom != "" {
+		localVar := "count" + fieldName
+		fmt.Fprintf(w, "\t%s = make([]%s, int(%s))\n", target, dataType, localVar)
+		fmt.Fprintf(w, "\tfor i := range %s {\n", target)
+		codecScalarRead(w, target+"[i]", dataType)
+		fmt.Fprintln(w, "\t}")
+		return
+	}
+	codecScalarRead(w, target, dataType)
+}
+
+// binapiCodecPrimitives lists the fixed-width Go types produced by
+// convertToGoType that are encoded directly via encoding/binary; any other
+// type is assumed to be a nested generated type with its own Size/Marshal/
+// Unmarshal methods.
+var binapiCodecPrimitives = map[string]int{
+	"bool":   1,
+	"byte":   1,
+	"uint8":  1,
+	"int8":   1,
+	"uint16": 2,
+	"int16":  2,
+	"uint32": 4,
+	"int32":  4,
+	"uint64": 8,
+	"int64":  8,
 }
 
 // generateType writes generated code for the type into w
@@ -595,6 +1020,18 @@ func generateType(ctx *context, w io.Writer, typ *Type) {
 		generateCrcGetter(w, name, typ.CRC)
 	}
 
+	if ctx.generateCodec {
+		var codecFields []Field
+		for _, field := range typ.Fields {
+			switch strings.ToLower(field.Name) {
+			case crcField, msgIdField:
+				continue
+			}
+			codecFields = append(codecFields, field)
+		}
+		generateFieldsCodec(ctx, w, name, codecFields)
+	}
+
 	fmt.Fprintln(w)
 }
 
@@ -663,6 +1100,24 @@ func generateMessage(ctx *context, w io.Writer, msg *Message) {
 	// generate message type getter method
 	generateMessageTypeGetter(w, name, msgType)
 
+	if ctx.generateCodec {
+		var codecFields []Field
+		n := 0
+		for _, field := range msg.Fields {
+			switch strings.ToLower(field.Name) {
+			case crcField, msgIdField:
+				continue
+			case clientIndexField, contextField:
+				if n == 0 {
+					continue
+				}
+			}
+			n++
+			codecFields = append(codecFields, field)
+		}
+		generateFieldsCodec(ctx, w, name, codecFields)
+	}
+
 	fmt.Fprintln(w)
 }
 
@@ -673,8 +1128,9 @@ func generateField(ctx *context, w io.Writer, fields []Field, i int) {
 	fieldName := strings.TrimPrefix(field.Name, "_")
 	fieldName = camelCaseName(fieldName)
 
-	// generate length field for strings
-	if field.Type == "string" {
+	// generate length field for strings (struc-tag mode only; codec mode
+	// derives the length from len(field) when marshaling)
+	if field.Type == "string" && !ctx.generateCodec {
 		fmt.Fprintf(w, "\tXXX_%sLen uint32 `struc:\"sizeof=%s\"`\n", fieldName, fieldName)
 	}
 
@@ -692,15 +1148,17 @@ func generateField(ctx *context, w io.Writer, fields []Field, i int) {
 
 	fieldTags := map[string]string{}
 
-	if field.Length > 0 {
-		// fixed size array
-		fieldTags["struc"] = fmt.Sprintf("[%d]%s", field.Length, dataType)
-	} else {
-		for _, f := range fields {
-			if f.SizeFrom == field.Name {
-				// variable sized array
-				sizeOfName := camelCaseName(f.Name)
-				fieldTags["struc"] = fmt.Sprintf("sizeof=%s", sizeOfName)
+	if !ctx.generateCodec {
+		if field.Length > 0 {
+			// fixed size array
+			fieldTags["struc"] = fmt.Sprintf("[%d]%s", field.Length, dataType)
+		} else {
+			for _, f := range fields {
+				if f.SizeFrom == field.Name {
+					// variable sized array
+					sizeOfName := camelCaseName(f.Name)
+					fieldTags["struc"] = fmt.Sprintf("sizeof=%s", sizeOfName)
+				}
 			}
 		}
 	}
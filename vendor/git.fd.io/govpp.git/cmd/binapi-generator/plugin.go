@@ -0,0 +1,317 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Plugin generates additional content for a package being generated. The
+// built-in "core", "rpc" and "rest" plugins (registered by this file's
+// init) cover everything binapi-generator produced before plugins existed;
+// downstream projects can RegisterPlugin their own (mock generators, tracing
+// wrappers, ...) and select them with the -plugins flag instead of forking
+// this generator.
+type Plugin interface {
+	// Name identifies the plugin, e.g. for the -plugins flag.
+	Name() string
+	// Generate runs the plugin against the parsed package in ctx, writing
+	// into gen (the package's primary file) or a sibling file obtained via
+	// gen.NewFile.
+	Generate(ctx *context, gen *GeneratedFile) error
+}
+
+// pluginRegistry holds every Plugin registered via RegisterPlugin.
+var pluginRegistry = map[string]Plugin{}
+
+// RegisterPlugin registers a Plugin under its Name so it can be selected
+// with the -plugins flag. It panics on a duplicate name.
+func RegisterPlugin(p Plugin) {
+	name := p.Name()
+	if _, dup := pluginRegistry[name]; dup {
+		panic(fmt.Sprintf("binapi-generator: plugin %q registered twice", name))
+	}
+	pluginRegistry[name] = p
+}
+
+// defaultPlugins lists the plugins that run when -plugins is not given.
+var defaultPlugins = []string{"core", "rpc", "rest"}
+
+// resolvePlugins returns the Plugins to run for ctx, honoring -plugins when
+// set and falling back to defaultPlugins otherwise.
+func (ctx *context) resolvePlugins() ([]Plugin, error) {
+	names := ctx.plugins
+	if len(names) == 0 {
+		names = defaultPlugins
+	}
+
+	var plugins []Plugin
+	for _, name := range names {
+		p, ok := pluginRegistry[name]
+		if !ok {
+			return nil, fmt.Errorf("binapi-generator: unknown plugin %q (-plugins flag)", name)
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+// GeneratedFile is the writer passed to Plugin.Generate. Writes against it
+// (directly, or via fmt.Fprint*) are buffered and appended to the package's
+// primary .ba.go file after the fixed import block, once every plugin has
+// run (see generatePackage). Plugins needing imports beyond the fixed set in
+// generateImports should add them through Import rather than writing an
+// "import" line themselves, and plugins producing whole separate files
+// (e.g. a "*_rpc.ba.go") should use NewFile instead of writing into the
+// primary file.
+type GeneratedFile struct {
+	ctx     *context
+	body    bytes.Buffer      // buffered primary-file content, flushed after the fixed import block
+	imports map[string]string // import path -> alias, requested via Import
+	order   []string          // import paths in first-requested order, for deterministic output
+	extra   []*extraFile
+}
+
+// extraFile is a sibling output file requested by a plugin via NewFile.
+type extraFile struct {
+	path string
+	buf  bytes.Buffer
+}
+
+// newGeneratedFile returns a GeneratedFile that buffers its primary content
+// and derives sibling file paths from ctx.outputFile.
+func newGeneratedFile(ctx *context) *GeneratedFile {
+	return &GeneratedFile{ctx: ctx, imports: map[string]string{}}
+}
+
+// Write implements io.Writer by appending to the buffered primary file.
+func (g *GeneratedFile) Write(p []byte) (int, error) {
+	return g.body.Write(p)
+}
+
+// Import records that the primary output file needs path imported, aliased
+// as alias (or path's last segment if alias is empty), and returns the alias
+// to use when referencing it. Imports are deduplicated, so plugins sharing
+// an import don't produce conflicting "import" lines. The actual "import"
+// line is written later by writeExtraImports, just ahead of the fixed import
+// block generateImports writes - not inline at the point Import is called,
+// since that point may fall after declarations Generate already buffered.
+func (g *GeneratedFile) Import(path, alias string) string {
+	if alias == "" {
+		alias = path[strings.LastIndex(path, "/")+1:]
+	}
+	if existing, ok := g.imports[path]; ok {
+		return existing
+	}
+	g.imports[path] = alias
+	g.order = append(g.order, path)
+	return alias
+}
+
+// writeExtraImports writes an "import" line for every path requested via
+// Import, in request order, into w. Called right before generateImports so
+// plugin-requested imports land next to the built-in ones, ahead of the
+// declarations generateImports writes after its import lines.
+func (g *GeneratedFile) writeExtraImports(w io.Writer) {
+	for _, path := range g.order {
+		fmt.Fprintf(w, "import %s \"%s\"\n", g.imports[path], path)
+	}
+}
+
+// NewFile returns a Writer for a sibling output file named
+// <package><suffix>.ba.go (e.g. suffix "_rpc" produces "interfaces_rpc.ba.go"
+// alongside "interfaces.ba.go"). The file is written to disk once every
+// plugin has run; see generatePackage.
+func (g *GeneratedFile) NewFile(suffix string) io.Writer {
+	dir := filepath.Dir(g.ctx.outputFile)
+	path := filepath.Join(dir, g.ctx.packageName+suffix+outputFileExt)
+	f := &extraFile{path: path}
+	g.extra = append(g.extra, f)
+	return &f.buf
+}
+
+// flush appends the buffered primary-file content to w, then writes every
+// sibling file collected via NewFile to disk.
+func (g *GeneratedFile) flush(w io.Writer) error {
+	if _, err := g.body.WriteTo(w); err != nil {
+		return fmt.Errorf("binapi-generator: writing package body: %v", err)
+	}
+	for _, f := range g.extra {
+		if err := ioutil.WriteFile(f.path, f.buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("binapi-generator: writing %s: %v", f.path, err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	RegisterPlugin(corePlugin{})
+	RegisterPlugin(rpcPlugin{})
+	RegisterPlugin(restPlugin{})
+}
+
+// corePlugin emits the enums, aliases, types, unions and messages of a
+// package, plus the message registrations and AllMessages list. It is
+// always part of defaultPlugins; a binapi package without it would have no
+// message types for the "rpc"/"rest" plugins to reference.
+type corePlugin struct{}
+
+func (corePlugin) Name() string { return "core" }
+
+func (corePlugin) Generate(ctx *context, gen *GeneratedFile) error {
+	w := gen
+
+	// generate enums
+	if len(ctx.packageData.Enums) > 0 {
+		fmt.Fprintf(w, "/* Enums */\n\n")
+
+		for _, enum := range ctx.packageData.Enums {
+			generateEnum(ctx, w, &enum)
+		}
+	}
+
+	// generate aliases
+	if len(ctx.packageData.Aliases) > 0 {
+		fmt.Fprintf(w, "/* Aliases */\n\n")
+
+		for _, alias := range ctx.packageData.Aliases {
+			generateAlias(ctx, w, &alias)
+		}
+	}
+
+	// generate types
+	if len(ctx.packageData.Types) > 0 {
+		fmt.Fprintf(w, "/* Types */\n\n")
+
+		for _, typ := range ctx.packageData.Types {
+			generateType(ctx, w, &typ)
+		}
+	}
+
+	// generate unions
+	if len(ctx.packageData.Unions) > 0 {
+		fmt.Fprintf(w, "/* Unions */\n\n")
+
+		for _, union := range ctx.packageData.Unions {
+			generateUnion(ctx, w, &union)
+		}
+	}
+
+	// generate messages
+	if len(ctx.packageData.Messages) > 0 {
+		fmt.Fprintf(w, "/* Messages */\n\n")
+
+		for _, msg := range ctx.packageData.Messages {
+			generateMessage(ctx, w, &msg)
+		}
+
+		// generate message registrations
+		fmt.Fprintln(w, "func init() {")
+		for _, msg := range ctx.packageData.Messages {
+			name := camelCaseName(msg.Name)
+			fmt.Fprintf(w, "\tapi.RegisterMessage((*%s)(nil), \"%s\")\n", name, ctx.moduleName+"."+name)
+		}
+		fmt.Fprintln(w, "}")
+		fmt.Fprintln(w)
+
+		// generate list of messages
+		fmt.Fprintf(w, "// AllMessages returns list of all messages in this module.\n")
+		fmt.Fprintln(w, "func AllMessages() []api.Message {")
+		fmt.Fprintln(w, "\treturn []api.Message{")
+		for _, msg := range ctx.packageData.Messages {
+			name := camelCaseName(msg.Name)
+			fmt.Fprintf(w, "\t(*%s)(nil),\n", name)
+		}
+		fmt.Fprintln(w, "}")
+		fmt.Fprintln(w, "}")
+		fmt.Fprintln(w)
+
+		generateMessageResolver(ctx, w, ctx.packageData.Messages)
+	}
+
+	return nil
+}
+
+// generateMessageResolver writes the Messages map, LookupMessage and
+// CheckCompatibility for a module's messages into w, so that clients can
+// verify their generated bindings against the CRCs known by a connected VPP
+// instead of grepping VersionCrc by hand.
+func generateMessageResolver(ctx *context, w io.Writer, messages []Message) {
+	fmt.Fprintf(w, "// Messages lists all messages in this module, keyed by \"<name>_<crc>\" for CRC-aware lookup.\n")
+	fmt.Fprintln(w, "var Messages = map[string]api.Message{")
+	for _, msg := range messages {
+		name := camelCaseName(msg.Name)
+		crc := strings.TrimPrefix(msg.CRC, "0x")
+		fmt.Fprintf(w, "\t%q: (*%s)(nil),\n", msg.Name+"_"+crc, name)
+	}
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "// LookupMessage returns the message registered under name with matching crc, or nil if none matches.\n")
+	fmt.Fprintln(w, "func LookupMessage(name, crc string) api.Message {")
+	fmt.Fprintln(w, "\treturn Messages[name+\"_\"+crc]")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "// CheckCompatibility verifies that every message in this module resolves to\n")
+	fmt.Fprintf(w, "// a matching CRC in the message-ID table of ch, returning a structured error\n")
+	fmt.Fprintf(w, "// listing any message whose CRC doesn't match the connected VPP.\n")
+	fmt.Fprintln(w, "func CheckCompatibility(ch api.Channel) error {")
+	fmt.Fprintln(w, "\tvar incompatible []string")
+	fmt.Fprintln(w, "\tfor _, msg := range AllMessages() {")
+	fmt.Fprintln(w, "\t\tif _, err := ch.GetMessageID(msg); err != nil {")
+	fmt.Fprintln(w, "\t\t\tincompatible = append(incompatible, fmt.Sprintf(\"%s (crc %s): %v\", msg.GetMessageName(), msg.GetCrcString(), err))")
+	fmt.Fprintln(w, "\t\t}")
+	fmt.Fprintln(w, "\t}")
+	fmt.Fprintln(w, "\tif len(incompatible) > 0 {")
+	fmt.Fprintln(w, "\t\treturn fmt.Errorf(\"module %s: messages incompatible with connected VPP: %s\", ModuleName, strings.Join(incompatible, \"; \"))")
+	fmt.Fprintln(w, "\t}")
+	fmt.Fprintln(w, "\treturn nil")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+}
+
+// rpcPlugin emits the Service interface and its channel-backed client (see
+// generateServices), gated on -include-services the same way it always was.
+type rpcPlugin struct{}
+
+func (rpcPlugin) Name() string { return "rpc" }
+
+func (rpcPlugin) Generate(ctx *context, gen *GeneratedFile) error {
+	if !ctx.includeServices || len(ctx.packageData.Services) == 0 {
+		return nil
+	}
+	generateServices(ctx, gen, ctx.packageData.Services)
+	return nil
+}
+
+// restPlugin emits the HTTPHandler/Routes REST surface for a package's
+// services (see generateHTTPHandlers), gated on -http-handlers.
+type restPlugin struct{}
+
+func (restPlugin) Name() string { return "rest" }
+
+func (restPlugin) Generate(ctx *context, gen *GeneratedFile) error {
+	if !ctx.includeServices || !ctx.includeHTTPHandlers || len(ctx.packageData.Services) == 0 {
+		return nil
+	}
+	generateHTTPHandlers(ctx, gen, ctx.packageData.Services)
+	return nil
+}